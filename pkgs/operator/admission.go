@@ -0,0 +1,289 @@
+package operator
+
+import (
+	"crypto/rsa"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultAdmissionQueueDepth bounds how many init requests may wait for a
+// free ceremony slot before InitInstance starts rejecting new arrivals
+// outright, rather than growing the queue without bound under sustained
+// overload.
+const DefaultAdmissionQueueDepth = 4096
+
+// DefaultAdmissionWait is how long an enqueued init request will wait for
+// a free ceremony slot before giving up with ErrQueueTimeout.
+const DefaultAdmissionWait = 30 * time.Second
+
+var ErrQueueFull = errors.New("admission queue is full, please retry later")
+var ErrQueueTimeout = errors.New("timed out waiting for a free DKG instance slot")
+
+var (
+	admissionEnqueued = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ssv_dkg_admission_queue_enqueued_total",
+		Help: "Number of init requests admitted into the admission queue.",
+	})
+	admissionDequeued = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ssv_dkg_admission_queue_dequeued_total",
+		Help: "Number of init requests dequeued and handed to CreateInstance.",
+	})
+	admissionTimedOut = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ssv_dkg_admission_queue_timeout_total",
+		Help: "Number of init requests that timed out waiting in the admission queue.",
+	})
+	admissionRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ssv_dkg_admission_queue_rejected_total",
+		Help: "Number of init requests rejected because the admission queue was full.",
+	})
+)
+
+// admissionTicket is one initiator's request to start a ceremony, waiting
+// for a free slot among maxConcurrent concurrent live instances.
+type admissionTicket struct {
+	reqID       InstanceID
+	initiatorFP InitiatorFingerprint
+	arrival     time.Time
+	admitted    chan struct{}
+}
+
+// AdmissionQueue smooths bursts of init requests into maxConcurrent
+// concurrent ceremonies, queueing requests over that cap. A single
+// dispatcher goroutine drains the queue round-robin by initiator
+// fingerprint, so one initiator flooding the queue cannot starve everyone
+// else. InitInstance checks len(s.Instances) before it reaches the queue:
+// when MaxInstances instances are still live after stale ones are
+// cleaned, it returns ErrMaxInstances without enqueueing the request.
+type AdmissionQueue struct {
+	maxConcurrent int
+	maxDepth      int
+	maxWait       time.Duration
+
+	mtx     sync.Mutex
+	active  int
+	byInit  map[InitiatorFingerprint][]*admissionTicket
+	order   []InitiatorFingerprint // initiator fingerprints with pending tickets, round-robin order
+	cursor  int
+	pending int
+	wake    chan struct{} // signals the dispatcher to re-evaluate the queue
+}
+
+// NewAdmissionQueue builds a queue that allows up to maxConcurrent
+// ceremonies in flight at once, a queue depth of maxDepth waiting
+// tickets, and a per-ticket wait of maxWait before it times out. The
+// dispatcher goroutine runs for the lifetime of the process.
+func NewAdmissionQueue(maxConcurrent, maxDepth int, maxWait time.Duration) *AdmissionQueue {
+	q := &AdmissionQueue{
+		maxConcurrent: maxConcurrent,
+		maxDepth:      maxDepth,
+		maxWait:       maxWait,
+		byInit:        make(map[InitiatorFingerprint][]*admissionTicket),
+		wake:          make(chan struct{}, 1),
+	}
+	go q.dispatchLoop()
+	return q
+}
+
+// Depth returns the number of tickets currently waiting for a slot.
+func (q *AdmissionQueue) Depth() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.pending
+}
+
+// AdmissionQueueStatus is a point-in-time snapshot of the queue, exposed through the
+// introspection API so operators can see admission pressure without
+// reasoning about Prometheus counters.
+type AdmissionQueueStatus struct {
+	Depth         int `json:"depth"`
+	Active        int `json:"active"`
+	MaxConcurrent int `json:"max_concurrent"`
+	MaxDepth      int `json:"max_depth"`
+}
+
+func (q *AdmissionQueue) Status() AdmissionQueueStatus {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return AdmissionQueueStatus{
+		Depth:         q.pending,
+		Active:        q.active,
+		MaxConcurrent: q.maxConcurrent,
+		MaxDepth:      q.maxDepth,
+	}
+}
+
+// Admit blocks the caller until a ceremony slot is free (enqueueing and
+// fairly scheduling it against other initiators in the meantime), the
+// queue is full, or maxWait elapses. On success, the returned release
+// func must be called once the slot can be handed back, i.e. once
+// CreateInstance has returned (successfully or not).
+func (q *AdmissionQueue) Admit(reqID InstanceID, initiatorPubKey *rsa.PublicKey) (release func(), err error) {
+	fp, err := NewInitiatorFingerprint(initiatorPubKey)
+	if err != nil {
+		return nil, err
+	}
+	ticket := &admissionTicket{
+		reqID:       reqID,
+		initiatorFP: fp,
+		arrival:     time.Now(),
+		admitted:    make(chan struct{}),
+	}
+
+	q.mtx.Lock()
+	if q.pending >= q.maxDepth {
+		q.mtx.Unlock()
+		admissionRejected.Inc()
+		return nil, ErrQueueFull
+	}
+	q.enqueueLocked(ticket)
+	q.mtx.Unlock()
+	admissionEnqueued.Inc()
+	q.signal()
+
+	timer := time.NewTimer(q.maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-ticket.admitted:
+		admissionDequeued.Inc()
+		return func() { q.release() }, nil
+	case <-timer.C:
+		q.mtx.Lock()
+		removed := q.dequeueLocked(ticket)
+		q.mtx.Unlock()
+		if !removed {
+			// The dispatcher admitted it in the race between the timer
+			// firing and us taking the lock; honor the admission.
+			<-ticket.admitted
+			admissionDequeued.Inc()
+			return func() { q.release() }, nil
+		}
+		admissionTimedOut.Inc()
+		return nil, ErrQueueTimeout
+	}
+}
+
+func (q *AdmissionQueue) release() {
+	q.mtx.Lock()
+	q.active--
+	q.mtx.Unlock()
+	q.signal()
+}
+
+func (q *AdmissionQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchLoop admits tickets round-robin across initiator fingerprints
+// whenever a slot is free, waking whenever Admit enqueues a ticket or
+// release frees a slot.
+func (q *AdmissionQueue) dispatchLoop() {
+	for range q.wake {
+		q.mtx.Lock()
+		for q.active < q.maxConcurrent {
+			ticket, ok := q.nextLocked()
+			if !ok {
+				break
+			}
+			q.active++
+			close(ticket.admitted)
+		}
+		q.mtx.Unlock()
+	}
+}
+
+// nextLocked picks the next ticket round-robin by initiator fingerprint
+// and removes it from the queue. Callers must hold q.mtx.
+func (q *AdmissionQueue) nextLocked() (*admissionTicket, bool) {
+	if len(q.order) == 0 {
+		return nil, false
+	}
+	q.cursor %= len(q.order)
+	fp := q.order[q.cursor]
+	tickets := q.byInit[fp]
+	ticket := tickets[0]
+	q.byInit[fp] = tickets[1:]
+	if len(q.byInit[fp]) == 0 {
+		delete(q.byInit, fp)
+		q.order = append(q.order[:q.cursor], q.order[q.cursor+1:]...)
+	} else {
+		q.cursor++
+	}
+	q.pending--
+	return ticket, true
+}
+
+func (q *AdmissionQueue) enqueueLocked(ticket *admissionTicket) {
+	if _, ok := q.byInit[ticket.initiatorFP]; !ok {
+		q.order = append(q.order, ticket.initiatorFP)
+	}
+	q.byInit[ticket.initiatorFP] = append(q.byInit[ticket.initiatorFP], ticket)
+	q.pending++
+}
+
+// dequeueLocked removes ticket from the queue if it is still waiting,
+// returning false if the dispatcher already admitted it.
+func (q *AdmissionQueue) dequeueLocked(ticket *admissionTicket) bool {
+	tickets := q.byInit[ticket.initiatorFP]
+	for i, t := range tickets {
+		if t == ticket {
+			q.byInit[ticket.initiatorFP] = append(tickets[:i], tickets[i+1:]...)
+			if len(q.byInit[ticket.initiatorFP]) == 0 {
+				delete(q.byInit, ticket.initiatorFP)
+				for j, fp := range q.order {
+					if fp == ticket.initiatorFP {
+						q.order = append(q.order[:j], q.order[j+1:]...)
+						break
+					}
+				}
+			}
+			q.pending--
+			return true
+		}
+	}
+	return false
+}
+
+// AdmitInit is the entry point InitInstance calls before starting a
+// ceremony: it blocks for a free slot (fairly, across initiators) and
+// returns a release func to call once the ceremony has been created (or
+// failed to be). When no AdmissionQueue is configured, it is a no-op, so
+// Switch keeps working without one.
+func (s *Switch) AdmitInit(reqID InstanceID, initiatorPubKey *rsa.PublicKey) (func(), error) {
+	if s.Admission == nil {
+		return func() {}, nil
+	}
+	return s.Admission.Admit(reqID, initiatorPubKey)
+}
+
+// ReserveRecovered reserves an admission slot for an instance Recover is
+// rebuilding on startup, directly incrementing active rather than
+// enqueueing through Admit's fairness queue: a recovered instance already
+// held its slot before the restart and isn't a new initiator competing
+// for one, so it must not wait behind (or be throttled fairly against)
+// fresh Admit calls. It never blocks and never fails, since refusing to
+// recover an instance that was already live would be worse than
+// temporarily letting active exceed maxConcurrent. When no
+// AdmissionQueue is configured, it is a no-op.
+func (s *Switch) ReserveRecovered() func() {
+	if s.Admission == nil {
+		return func() {}
+	}
+	return s.Admission.ReserveRecovered()
+}
+
+// ReserveRecovered increments active directly, bypassing the fairness
+// queue dispatchLoop otherwise enforces. See Switch.ReserveRecovered.
+func (q *AdmissionQueue) ReserveRecovered() func() {
+	q.mtx.Lock()
+	q.active++
+	q.mtx.Unlock()
+	return func() { q.release() }
+}
@@ -0,0 +1,154 @@
+package operator
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRSAKey(t *testing.T) *rsa.PublicKey {
+	t.Helper()
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &pk.PublicKey
+}
+
+func TestAdmissionQueueBurstRespectsConcurrencyLimit(t *testing.T) {
+	q := NewAdmissionQueue(2, DefaultAdmissionQueueDepth, time.Second)
+	pk := newTestRSAKey(t)
+
+	var wg sync.WaitGroup
+	// Sized to the concurrency limit: a third holder would find it full.
+	admittedAtOnce := make(chan struct{}, 2)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := q.Admit([24]byte{byte(i)}, pk)
+			require.NoError(t, err)
+			select {
+			case admittedAtOnce <- struct{}{}:
+			default:
+				t.Errorf("more than 2 ceremonies admitted at once")
+				admittedAtOnce <- struct{}{}
+			}
+			time.Sleep(10 * time.Millisecond)
+			<-admittedAtOnce
+			release()
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 0, q.Depth())
+}
+
+func TestAdmissionQueueTimesOutUnderSustainedOverload(t *testing.T) {
+	q := NewAdmissionQueue(1, DefaultAdmissionQueueDepth, 20*time.Millisecond)
+	pk := newTestRSAKey(t)
+
+	release, err := q.Admit([24]byte{1}, pk)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = q.Admit([24]byte{2}, pk)
+	require.ErrorIs(t, err, ErrQueueTimeout)
+}
+
+func TestAdmissionQueueRejectsBeyondMaxDepth(t *testing.T) {
+	q := NewAdmissionQueue(1, 1, time.Second)
+	pk := newTestRSAKey(t)
+
+	release, err := q.Admit([24]byte{1}, pk)
+	require.NoError(t, err)
+	defer release()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = q.Admit([24]byte{2}, pk) // occupies the single queue slot
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = q.Admit([24]byte{3}, pk)
+	require.ErrorIs(t, err, ErrQueueFull)
+	wg.Wait()
+}
+
+func TestAdmissionQueueFairnessAcrossInitiators(t *testing.T) {
+	q := NewAdmissionQueue(1, DefaultAdmissionQueueDepth, time.Second)
+	busyPk := newTestRSAKey(t)
+	quietPk := newTestRSAKey(t)
+
+	release, err := q.Admit([24]byte{0}, busyPk)
+	require.NoError(t, err)
+
+	// Flood the queue with tickets from one initiator, then one from
+	// another; the single other initiator should not starve behind them.
+	var wg sync.WaitGroup
+	for i := 1; i <= 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := q.Admit([24]byte{byte(i)}, busyPk)
+			if err == nil {
+				r()
+			}
+		}(i)
+	}
+	time.Sleep(5 * time.Millisecond) // let the flood enqueue first
+
+	quietDone := make(chan struct{})
+	go func() {
+		r, err := q.Admit([24]byte{9}, quietPk)
+		require.NoError(t, err)
+		r()
+		close(quietDone)
+	}()
+
+	release()
+
+	select {
+	case <-quietDone:
+	case <-time.After(time.Second):
+		t.Fatal("quiet initiator starved behind a single busy initiator's flood")
+	}
+	wg.Wait()
+}
+
+func TestReserveRecoveredDoesNotWaitBehindPendingAdmits(t *testing.T) {
+	q := NewAdmissionQueue(1, DefaultAdmissionQueueDepth, time.Second)
+	pk := newTestRSAKey(t)
+
+	// Fill the single slot and queue a second Admit call behind it, then
+	// confirm ReserveRecovered still returns immediately instead of
+	// waiting in that fairness queue.
+	release, err := q.Admit([24]byte{1}, pk)
+	require.NoError(t, err)
+	defer release()
+
+	admitDone := make(chan struct{})
+	go func() {
+		r, err := q.Admit([24]byte{2}, pk)
+		if err == nil {
+			r()
+		}
+		close(admitDone)
+	}()
+	time.Sleep(5 * time.Millisecond) // let the second Admit enqueue
+
+	reserveDone := make(chan func())
+	go func() { reserveDone <- q.ReserveRecovered() }()
+
+	select {
+	case recoveredRelease := <-reserveDone:
+		recoveredRelease()
+	case <-time.After(time.Second):
+		t.Fatal("ReserveRecovered must not block behind Admit's fairness queue")
+	}
+
+	<-admitDone
+}
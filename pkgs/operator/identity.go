@@ -0,0 +1,125 @@
+package operator
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// OperatorID identifies an operator within a DKG ceremony's operator set.
+// It is a distinct type over the uint64 IDs carried by wire.Operator so
+// that operator IDs, instance IDs and raw message counters can no longer
+// be mixed up by the compiler.
+type OperatorID uint64
+
+func (id OperatorID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func (id OperatorID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uint64(id))
+}
+
+// operatorIDSSZSize is the encoded length of OperatorID: a little-endian
+// uint64, matching the wire.Operator.ID field it is built from.
+const operatorIDSSZSize = 8
+
+func (id OperatorID) MarshalSSZ() ([]byte, error) {
+	buf := make([]byte, operatorIDSSZSize)
+	binary.LittleEndian.PutUint64(buf, uint64(id))
+	return buf, nil
+}
+
+func (id *OperatorID) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != operatorIDSSZSize {
+		return fmt.Errorf("invalid OperatorID length, expected %d, got %d", operatorIDSSZSize, len(buf))
+	}
+	*id = OperatorID(binary.LittleEndian.Uint64(buf))
+	return nil
+}
+
+func (id OperatorID) SizeSSZ() int {
+	return operatorIDSSZSize
+}
+
+// OperatorPubKey is the SHA-256 digest of an operator's DER-encoded RSA
+// public key. Switch.CreateInstance used to match operators by comparing
+// raw []byte keys with bytes.Equal; hashing once at the boundary turns
+// that into an ordinary map lookup and a compile-time-checked type.
+type OperatorPubKey [32]byte
+
+// NewOperatorPubKey validates and hashes a DER-encoded RSA public key as
+// found on wire.Operator.PubKey.
+func NewOperatorPubKey(der []byte) (OperatorPubKey, error) {
+	if len(der) == 0 {
+		return OperatorPubKey{}, errors.New("empty operator public key")
+	}
+	return sha256.Sum256(der), nil
+}
+
+func (k OperatorPubKey) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+func (k OperatorPubKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+func (k OperatorPubKey) MarshalSSZ() ([]byte, error) {
+	return append([]byte{}, k[:]...), nil
+}
+
+func (k *OperatorPubKey) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != len(k) {
+		return fmt.Errorf("invalid OperatorPubKey length, expected %d, got %d", len(k), len(buf))
+	}
+	copy(k[:], buf)
+	return nil
+}
+
+func (k OperatorPubKey) SizeSSZ() int {
+	return len(k)
+}
+
+// InitiatorFingerprint is the SHA-256 digest of an initiator's RSA modulus
+// (N), matching the fingerprint InitInstance already logs and the one
+// CreateInstance now attaches to every instWrapper it creates.
+type InitiatorFingerprint [32]byte
+
+// NewInitiatorFingerprint validates and fingerprints an initiator's RSA
+// public key.
+func NewInitiatorFingerprint(pub *rsa.PublicKey) (InitiatorFingerprint, error) {
+	if pub == nil || pub.N == nil {
+		return InitiatorFingerprint{}, errors.New("nil initiator public key")
+	}
+	return sha256.Sum256(pub.N.Bytes()), nil
+}
+
+func (f InitiatorFingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+func (f InitiatorFingerprint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+func (f InitiatorFingerprint) MarshalSSZ() ([]byte, error) {
+	return append([]byte{}, f[:]...), nil
+}
+
+func (f *InitiatorFingerprint) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != len(f) {
+		return fmt.Errorf("invalid InitiatorFingerprint length, expected %d, got %d", len(f), len(buf))
+	}
+	copy(f[:], buf)
+	return nil
+}
+
+func (f InitiatorFingerprint) SizeSSZ() int {
+	return len(f)
+}
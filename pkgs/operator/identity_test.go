@@ -0,0 +1,96 @@
+package operator
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperatorIDStringAndJSON(t *testing.T) {
+	id := OperatorID(42)
+	require.Equal(t, "42", id.String())
+	b, err := id.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, "42", string(b))
+}
+
+func TestOperatorIDSSZRoundTrip(t *testing.T) {
+	id := OperatorID(42)
+	require.Equal(t, 8, id.SizeSSZ())
+
+	buf, err := id.MarshalSSZ()
+	require.NoError(t, err)
+	require.Len(t, buf, id.SizeSSZ())
+
+	var decoded OperatorID
+	require.NoError(t, decoded.UnmarshalSSZ(buf))
+	require.Equal(t, id, decoded)
+
+	require.Error(t, decoded.UnmarshalSSZ(buf[:4]))
+}
+
+func TestNewOperatorPubKey(t *testing.T) {
+	_, err := NewOperatorPubKey(nil)
+	require.Error(t, err)
+
+	k1, err := NewOperatorPubKey([]byte("der-bytes-a"))
+	require.NoError(t, err)
+	k2, err := NewOperatorPubKey([]byte("der-bytes-a"))
+	require.NoError(t, err)
+	require.Equal(t, k1, k2)
+
+	k3, err := NewOperatorPubKey([]byte("der-bytes-b"))
+	require.NoError(t, err)
+	require.NotEqual(t, k1, k3)
+}
+
+func TestOperatorPubKeySSZRoundTrip(t *testing.T) {
+	k, err := NewOperatorPubKey([]byte("der-bytes-a"))
+	require.NoError(t, err)
+	require.Equal(t, 32, k.SizeSSZ())
+
+	buf, err := k.MarshalSSZ()
+	require.NoError(t, err)
+	require.Len(t, buf, k.SizeSSZ())
+
+	var decoded OperatorPubKey
+	require.NoError(t, decoded.UnmarshalSSZ(buf))
+	require.Equal(t, k, decoded)
+
+	require.Error(t, decoded.UnmarshalSSZ(buf[:10]))
+}
+
+func TestNewInitiatorFingerprint(t *testing.T) {
+	_, err := NewInitiatorFingerprint(nil)
+	require.Error(t, err)
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	fp1, err := NewInitiatorFingerprint(&pk.PublicKey)
+	require.NoError(t, err)
+	fp2, err := NewInitiatorFingerprint(&pk.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, fp1, fp2)
+	require.Len(t, fp1.String(), 64) // hex-encoded sha256
+}
+
+func TestInitiatorFingerprintSSZRoundTrip(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	fp, err := NewInitiatorFingerprint(&pk.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, 32, fp.SizeSSZ())
+
+	buf, err := fp.MarshalSSZ()
+	require.NoError(t, err)
+	require.Len(t, buf, fp.SizeSSZ())
+
+	var decoded InitiatorFingerprint
+	require.NoError(t, decoded.UnmarshalSSZ(buf))
+	require.Equal(t, fp, decoded)
+
+	require.Error(t, decoded.UnmarshalSSZ(buf[:10]))
+}
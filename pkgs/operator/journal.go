@@ -0,0 +1,358 @@
+package operator
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/bloxapp/ssv-dkg/pkgs/crypto"
+	"github.com/bloxapp/ssv-dkg/pkgs/wire"
+	"github.com/bloxapp/ssv/storage/basedb"
+	"github.com/bloxapp/ssv/storage/kv"
+	kyber_dkg "github.com/drand/kyber/share/dkg"
+	"go.uber.org/zap"
+)
+
+// journalPrefix namespaces every journal entry in BadgerDB, mirroring the
+// way reshare secrets are already stored under the "secret" prefix.
+var journalPrefix = []byte("instances")
+
+// journalMessage is a single SSZ-encoded wire.SignedTransport, tagged with
+// its direction so that replay only re-feeds the messages the instance
+// received rather than the ones it produced itself.
+type journalMessage struct {
+	Inbound bool
+	Data    []byte
+}
+
+// journalRecord is everything Switch.Recover needs to rebuild an instance
+// and replay the messages it had already seen before a restart.
+type journalRecord struct {
+	ReqID           InstanceID
+	InitiatorPubKey []byte // PKIX-encoded RSA public key, as found on wire.Init
+	InitBytes       []byte // SSZ-encoded wire.Init
+	InitTime        time.Time
+	Messages        []journalMessage // inbound and outbound SignedTransport, in order
+}
+
+// journal persists instance state to BadgerDB so a restarted operator can
+// pick live ceremonies back up instead of silently dropping them.
+type journal struct {
+	db *kv.BadgerDB
+}
+
+func newJournal(db *kv.BadgerDB) *journal {
+	return &journal{db: db}
+}
+
+// WriteInit creates (or overwrites) the journal entry for reqID with no
+// messages recorded yet. It must be called once CreateInstance has
+// succeeded, so a crash before that point simply loses nothing that ever
+// existed.
+func (j *journal) WriteInit(reqID InstanceID, initiatorPubKey []byte, initBytes []byte, initTime time.Time) error {
+	rec := journalRecord{
+		ReqID:           reqID,
+		InitiatorPubKey: initiatorPubKey,
+		InitBytes:       initBytes,
+		InitTime:        initTime,
+	}
+	return j.save(rec)
+}
+
+// AppendMessage records msg (an SSZ-encoded wire.SignedTransport) as the
+// next message processed for reqID. inbound marks whether this instance
+// received the message or produced it.
+func (j *journal) AppendMessage(reqID InstanceID, msg []byte, inbound bool) error {
+	rec, ok, err := j.Load(reqID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no journal entry for instance %x", reqID)
+	}
+	rec.Messages = append(rec.Messages, journalMessage{Inbound: inbound, Data: msg})
+	return j.save(rec)
+}
+
+// Load returns the journal entry for reqID, if any.
+func (j *journal) Load(reqID InstanceID) (journalRecord, bool, error) {
+	obj, ok, err := j.db.Get(journalPrefix, reqID[:])
+	if err != nil || !ok {
+		return journalRecord{}, ok, err
+	}
+	rec, err := decodeJournalRecord(reqID, obj.Value)
+	return rec, true, err
+}
+
+// Delete removes the journal entry for reqID, e.g. once an instance has
+// completed or been garbage collected.
+func (j *journal) Delete(reqID InstanceID) error {
+	return j.db.Delete(journalPrefix, reqID[:])
+}
+
+// All returns every journal entry currently stored, used on startup to
+// replay live instances.
+func (j *journal) All() ([]journalRecord, error) {
+	var recs []journalRecord
+	err := j.db.GetAll(journalPrefix, func(_ int, obj basedb.Obj) error {
+		rec, err := decodeJournalRecord(InstanceID(obj.Key), obj.Value)
+		if err != nil {
+			return err
+		}
+		recs = append(recs, rec)
+		return nil
+	})
+	return recs, err
+}
+
+// GC deletes journal entries older than maxAge and returns how many were
+// removed, keeping BadgerDB from accumulating ceremonies that are long
+// past MaxInstanceTime and will never be replayed. It reads only the
+// InitTime prefix of each record rather than going through All(), which
+// would decode every journaled message an open, frequently-replayed-to
+// instance has ever appended just to check its age.
+func (j *journal) GC(maxAge time.Duration) (int, error) {
+	var stale []InstanceID
+	err := j.db.GetAll(journalPrefix, func(_ int, obj basedb.Obj) error {
+		initTime, err := decodeJournalInitTime(obj.Value)
+		if err != nil {
+			return err
+		}
+		if time.Since(initTime) > maxAge {
+			stale = append(stale, InstanceID(obj.Key))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	cleaned := 0
+	for _, id := range stale {
+		if err := j.Delete(id); err != nil {
+			return cleaned, err
+		}
+		cleaned++
+	}
+	return cleaned, nil
+}
+
+func (j *journal) save(rec journalRecord) error {
+	return j.db.Set(journalPrefix, rec.ReqID[:], encodeJournalRecord(rec))
+}
+
+// encodeJournalRecord/decodeJournalRecord use a small length-prefixed
+// binary layout rather than SSZ: unlike the wire types, journal records
+// are never shared across the network, so there is no need for a fixed
+// schema.
+func encodeJournalRecord(rec journalRecord) []byte {
+	var out []byte
+	var tbuf [8]byte
+	binary.BigEndian.PutUint64(tbuf[:], uint64(rec.InitTime.UnixNano()))
+	out = append(out, tbuf[:]...)
+	out = appendLenPrefixed(out, rec.InitiatorPubKey)
+	out = appendLenPrefixed(out, rec.InitBytes)
+	var cbuf [4]byte
+	binary.BigEndian.PutUint32(cbuf[:], uint32(len(rec.Messages)))
+	out = append(out, cbuf[:]...)
+	for _, msg := range rec.Messages {
+		if msg.Inbound {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		out = appendLenPrefixed(out, msg.Data)
+	}
+	return out
+}
+
+// decodeJournalInitTime reads just the InitTime prefix written by
+// encodeJournalRecord, without decoding the rest of the record, for
+// callers like GC that only need a record's age.
+func decodeJournalInitTime(data []byte) (time.Time, error) {
+	if len(data) < 8 {
+		return time.Time{}, fmt.Errorf("journal record is truncated")
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data[:8]))), nil
+}
+
+func decodeJournalRecord(reqID InstanceID, data []byte) (journalRecord, error) {
+	rec := journalRecord{ReqID: reqID}
+	if len(data) < 8 {
+		return rec, fmt.Errorf("journal record for %x is truncated", reqID)
+	}
+	rec.InitTime = time.Unix(0, int64(binary.BigEndian.Uint64(data[:8])))
+	rest := data[8:]
+
+	var err error
+	rec.InitiatorPubKey, rest, err = readLenPrefixed(rest)
+	if err != nil {
+		return rec, err
+	}
+	rec.InitBytes, rest, err = readLenPrefixed(rest)
+	if err != nil {
+		return rec, err
+	}
+	if len(rest) < 4 {
+		return rec, fmt.Errorf("journal record for %x is missing message count", reqID)
+	}
+	count := binary.BigEndian.Uint32(rest[:4])
+	rest = rest[4:]
+	rec.Messages = make([]journalMessage, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(rest) < 1 {
+			return rec, fmt.Errorf("journal record for %x is missing a message direction flag", reqID)
+		}
+		inbound := rest[0] == 1
+		rest = rest[1:]
+		var msg []byte
+		msg, rest, err = readLenPrefixed(rest)
+		if err != nil {
+			return rec, err
+		}
+		rec.Messages = append(rec.Messages, journalMessage{Inbound: inbound, Data: msg})
+	}
+	return rec, nil
+}
+
+func appendLenPrefixed(out, data []byte) []byte {
+	var lbuf [4]byte
+	binary.BigEndian.PutUint32(lbuf[:], uint32(len(data)))
+	out = append(out, lbuf[:]...)
+	return append(out, data...)
+}
+
+func readLenPrefixed(data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("truncated length prefix")
+	}
+	l := binary.BigEndian.Uint32(data[:4])
+	data = data[4:]
+	if uint32(len(data)) < l {
+		return nil, nil, fmt.Errorf("truncated field of length %d", l)
+	}
+	return data[:l], data[l:], nil
+}
+
+// Recover replays every journaled instance that is still within
+// MaxInstanceTime, rebuilding its dkg.LocalOwner and re-feeding every
+// journaled inbound message (outbound ones are skipped) before the Switch
+// accepts new traffic. It is called once
+// from NewSwitch; failures for an individual instance are logged and
+// skipped rather than aborting startup, since a single corrupt or
+// unrecoverable journal entry shouldn't take the whole operator down.
+func (s *Switch) Recover() error {
+	if s.Journal == nil {
+		return nil
+	}
+	recs, err := s.Journal.All()
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		logger := s.Logger.With(zap.String("reqid", hex.EncodeToString(rec.ReqID[:])))
+		if time.Since(rec.InitTime) > MaxInstanceTime {
+			logger.Debug("dropping expired journal entry")
+			if err := s.Journal.Delete(rec.ReqID); err != nil {
+				logger.Warn("failed to drop expired journal entry")
+			}
+			continue
+		}
+		if err := s.recoverInstance(rec); err != nil {
+			logger.Warn("failed to recover instance from journal, dropping it")
+			if delErr := s.Journal.Delete(rec.ReqID); delErr != nil {
+				logger.Warn("failed to drop unrecoverable journal entry")
+			}
+			continue
+		}
+		logger.Info("recovered instance from journal")
+	}
+	return nil
+}
+
+func (s *Switch) recoverInstance(rec journalRecord) error {
+	init := &wire.Init{}
+	if err := init.UnmarshalSSZ(rec.InitBytes); err != nil {
+		return err
+	}
+	initiatorPubKey, err := crypto.ParseRSAPubkey(rec.InitiatorPubKey)
+	if err != nil {
+		return err
+	}
+
+	// A journaled reshare must be recovered with its old secret share
+	// reloaded from s.DB, exactly like InitInstance's reshare branch;
+	// otherwise it would come back as a fresh, non-reshare instance and
+	// silently lose the old key share.
+	var secretShare *kyber_dkg.DistKeyShare
+	if len(init.NewOperators) != 0 {
+		var ok bool
+		secretShare, ok, err = s.loadReshareSecret(init.OldID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("no reshare secret found for old instance %x", init.OldID)
+		}
+	}
+
+	// Reserve the admission slot this instance already held before the
+	// restart, so the AdmissionQueue's accounting reflects it and doesn't
+	// let new initiators over-admit on top of every recovered ceremony.
+	release := s.ReserveRecovered()
+
+	inst, _, err := s.CreateInstance(rec.ReqID, init, initiatorPubKey, secretShare)
+	if err != nil {
+		release()
+		return err
+	}
+
+	for _, msg := range rec.Messages {
+		if !msg.Inbound {
+			continue // this instance produced it; CreateInstance already replayed our own Init broadcast
+		}
+		ts := &wire.SignedTransport{}
+		if err := ts.UnmarshalSSZ(msg.Data); err != nil {
+			inst.Close()
+			release()
+			return err
+		}
+		if err := inst.Process(ts.Signer, ts); err != nil {
+			inst.Close()
+			release()
+			return err
+		}
+		// Every round's worth of replayed inbound messages makes the
+		// local owner produce another broadcast onto the size-1
+		// respChan. It was already delivered to the other operators
+		// before the crash and journaled as an outbound message, so it
+		// is safe to discard here - left undrained, it would either
+		// deadlock the next Process call in this loop or be handed to
+		// the next real client's ReadResponse as if it were their
+		// response.
+		drainReplayedBroadcast(inst)
+	}
+
+	s.Mtx.Lock()
+	s.Instances[rec.ReqID] = inst
+	s.InstanceInitTime[rec.ReqID] = rec.InitTime
+	s.releases[rec.ReqID] = release
+	s.Mtx.Unlock()
+	return nil
+}
+
+// drainReplayedBroadcast discards a broadcast produced by replaying a
+// journaled message during Recover, if any. CreateInstance already drains
+// the very first broadcast (the Init response) into the value it returns,
+// so this only ever has anything to drain from the second replayed round
+// onward.
+func drainReplayedBroadcast(inst Instance) {
+	iw, ok := inst.(*instWrapper)
+	if !ok {
+		return
+	}
+	select {
+	case <-iw.respChan:
+	default:
+	}
+}
@@ -0,0 +1,95 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalRecordRoundTrip(t *testing.T) {
+	reqID := InstanceID{1, 2, 3}
+	rec := journalRecord{
+		ReqID:           reqID,
+		InitiatorPubKey: []byte("initiator-pubkey"),
+		InitBytes:       []byte("init-bytes"),
+		InitTime:        time.Unix(1700000000, 0).UTC(),
+		Messages: []journalMessage{
+			{Inbound: true, Data: []byte("exchange")},
+			{Inbound: false, Data: []byte("deal")},
+		},
+	}
+
+	decoded, err := decodeJournalRecord(reqID, encodeJournalRecord(rec))
+	require.NoError(t, err)
+	require.Equal(t, rec.ReqID, decoded.ReqID)
+	require.Equal(t, rec.InitiatorPubKey, decoded.InitiatorPubKey)
+	require.Equal(t, rec.InitBytes, decoded.InitBytes)
+	require.True(t, rec.InitTime.Equal(decoded.InitTime))
+	require.Equal(t, rec.Messages, decoded.Messages)
+}
+
+func TestJournalRecordRoundTripEmpty(t *testing.T) {
+	reqID := InstanceID{9}
+	rec := journalRecord{ReqID: reqID, InitTime: time.Unix(0, 0).UTC()}
+
+	decoded, err := decodeJournalRecord(reqID, encodeJournalRecord(rec))
+	require.NoError(t, err)
+	require.Empty(t, decoded.InitiatorPubKey)
+	require.Empty(t, decoded.InitBytes)
+	require.Empty(t, decoded.Messages)
+}
+
+func TestDecodeJournalRecordTruncated(t *testing.T) {
+	_, err := decodeJournalRecord(InstanceID{}, []byte{1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestDecodeJournalInitTimeMatchesFullDecode(t *testing.T) {
+	rec := journalRecord{
+		ReqID:           InstanceID{4, 5, 6},
+		InitiatorPubKey: []byte("initiator-pubkey"),
+		InitBytes:       []byte("init-bytes"),
+		InitTime:        time.Unix(1700000000, 0).UTC(),
+		Messages: []journalMessage{
+			{Inbound: true, Data: []byte("exchange")},
+		},
+	}
+	encoded := encodeJournalRecord(rec)
+
+	initTime, err := decodeJournalInitTime(encoded)
+	require.NoError(t, err)
+	require.True(t, rec.InitTime.Equal(initTime))
+
+	full, err := decodeJournalRecord(rec.ReqID, encoded)
+	require.NoError(t, err)
+	require.True(t, full.InitTime.Equal(initTime))
+}
+
+func TestDecodeJournalInitTimeTruncated(t *testing.T) {
+	_, err := decodeJournalInitTime([]byte{1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestDrainReplayedBroadcastDiscardsPendingBroadcast(t *testing.T) {
+	iw := &instWrapper{respChan: make(chan []byte, 1)}
+	iw.respChan <- []byte("deal round broadcast")
+
+	drainReplayedBroadcast(iw)
+
+	select {
+	case <-iw.respChan:
+		t.Fatal("respChan should have been drained")
+	default:
+	}
+}
+
+func TestDrainReplayedBroadcastNoPendingBroadcastIsNoop(t *testing.T) {
+	iw := &instWrapper{respChan: make(chan []byte, 1)}
+
+	require.NotPanics(t, func() { drainReplayedBroadcast(iw) })
+}
+
+func TestDrainReplayedBroadcastNonInstWrapperIsNoop(t *testing.T) {
+	require.NotPanics(t, func() { drainReplayedBroadcast(nil) })
+}
@@ -0,0 +1,57 @@
+package operator
+
+// Phase is a coarse, best-effort view of where a DKG instance is in its
+// lifecycle. It is derived from the number of protocol messages the
+// instance has processed rather than from LocalOwner internals, so it is
+// only meant for operator-facing introspection, not protocol logic.
+type Phase int
+
+const (
+	PhaseInit Phase = iota
+	PhaseExchange
+	PhaseDeal
+	PhaseResponse
+	PhaseComplete
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseInit:
+		return "init"
+	case PhaseExchange:
+		return "exchange"
+	case PhaseDeal:
+		return "deal"
+	case PhaseResponse:
+		return "response"
+	case PhaseComplete:
+		return "complete"
+	default:
+		return "unknown"
+	}
+}
+
+// phaseForMessageCount maps the number of individual protocol messages
+// processed so far to an approximate protocol phase: one round of
+// exchange, then one round of deals, then one round of responses.
+// peerCount is the number of other operators in the ceremony, since each
+// of them contributes one message per round to a single
+// MultipleSignedTransports batch - for any ceremony with more than two
+// operators, a round advances processedMsgs by peerCount, not by one.
+// Completion is detected separately, once the final response has been
+// read off respChan.
+func phaseForMessageCount(count, peerCount int) Phase {
+	if peerCount <= 0 {
+		peerCount = 1
+	}
+	switch {
+	case count <= 0:
+		return PhaseInit
+	case count <= peerCount:
+		return PhaseExchange
+	case count <= 2*peerCount:
+		return PhaseDeal
+	default:
+		return PhaseResponse
+	}
+}
@@ -0,0 +1,33 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhaseForMessageCountTwoOperators(t *testing.T) {
+	require.Equal(t, PhaseInit, phaseForMessageCount(0, 1))
+	require.Equal(t, PhaseExchange, phaseForMessageCount(1, 1))
+	require.Equal(t, PhaseDeal, phaseForMessageCount(2, 1))
+	require.Equal(t, PhaseResponse, phaseForMessageCount(3, 1))
+}
+
+func TestPhaseForMessageCountManyOperators(t *testing.T) {
+	// A 4-operator ceremony delivers 3 peer messages per round in a single
+	// MultipleSignedTransports batch, so a round advances processedMsgs
+	// by peerCount, not by one.
+	const peerCount = 3
+	require.Equal(t, PhaseInit, phaseForMessageCount(0, peerCount))
+	require.Equal(t, PhaseExchange, phaseForMessageCount(1, peerCount))
+	require.Equal(t, PhaseExchange, phaseForMessageCount(peerCount, peerCount))
+	require.Equal(t, PhaseDeal, phaseForMessageCount(peerCount+1, peerCount))
+	require.Equal(t, PhaseDeal, phaseForMessageCount(2*peerCount, peerCount))
+	require.Equal(t, PhaseResponse, phaseForMessageCount(2*peerCount+1, peerCount))
+}
+
+func TestPhaseForMessageCountZeroPeers(t *testing.T) {
+	// Defend against a malformed operatorIDs list (peerCount <= 0) rather
+	// than dividing by zero or reporting a nonsensical phase.
+	require.Equal(t, PhaseExchange, phaseForMessageCount(1, 0))
+}
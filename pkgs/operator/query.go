@@ -0,0 +1,170 @@
+package operator
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// InstanceInfo is the operator-facing summary of a live (or recently
+// finished) DKG instance, as returned by the introspection API.
+type InstanceInfo struct {
+	InstanceID           string       `json:"instance_id"`
+	InitTime             time.Time    `json:"init_time"`
+	OperatorIDs          []OperatorID `json:"operator_ids"`
+	Phase                string       `json:"phase"`
+	Reshare              bool         `json:"reshare"`
+	InitiatorFingerprint string       `json:"initiator_fingerprint"`
+	Elapsed              string       `json:"elapsed"`
+	TimeLeft             string       `json:"time_left"`
+}
+
+// InstanceDetail extends InstanceInfo with the instance's last observed
+// processing error, exposed through the per-instance detail endpoint.
+type InstanceDetail struct {
+	InstanceInfo
+	LastError string `json:"last_error,omitempty"`
+}
+
+// InstanceFilter narrows down the result of Switch.ListInstances.
+type InstanceFilter struct {
+	InitiatorFingerprint string        // hex-encoded, matches InstanceInfo.InitiatorFingerprint exactly
+	MinAge               time.Duration // only instances at least this old
+	Phase                string        // matches Phase.String(), empty means no filter
+}
+
+func (f InstanceFilter) matches(info InstanceInfo) bool {
+	if f.InitiatorFingerprint != "" && f.InitiatorFingerprint != info.InitiatorFingerprint {
+		return false
+	}
+	if f.MinAge > 0 {
+		elapsed, err := time.ParseDuration(info.Elapsed)
+		if err != nil || elapsed < f.MinAge {
+			return false
+		}
+	}
+	if f.Phase != "" && f.Phase != info.Phase {
+		return false
+	}
+	return true
+}
+
+func (s *Switch) infoFor(id InstanceID, inst Instance, initTime time.Time) InstanceInfo {
+	iw, _ := inst.(*instWrapper)
+	info := InstanceInfo{
+		InstanceID: hex.EncodeToString(id[:]),
+		InitTime:   initTime,
+		Elapsed:    time.Since(initTime).String(),
+		TimeLeft:   (MaxInstanceTime - time.Since(initTime)).String(),
+	}
+	if iw != nil {
+		iw.mtx.RLock()
+		info.Phase = iw.phase.String()
+		info.Reshare = iw.reshare
+		info.OperatorIDs = append([]OperatorID(nil), iw.operatorIDs...)
+		info.InitiatorFingerprint = iw.initiatorFingerprint.String()
+		iw.mtx.RUnlock()
+	}
+	return info
+}
+
+// ListInstances returns a snapshot of every tracked instance matching
+// filter, in no particular order.
+func (s *Switch) ListInstances(filter InstanceFilter) []InstanceInfo {
+	s.Mtx.RLock()
+	defer s.Mtx.RUnlock()
+
+	infos := make([]InstanceInfo, 0, len(s.Instances))
+	for id, inst := range s.Instances {
+		info := s.infoFor(id, inst, s.InstanceInitTime[id])
+		if filter.matches(info) {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// InstanceDetail returns the full detail, including the last processing
+// error, for a single instance.
+func (s *Switch) InstanceDetail(id InstanceID) (*InstanceDetail, error) {
+	s.Mtx.RLock()
+	inst, ok := s.Instances[id]
+	initTime := s.InstanceInitTime[id]
+	s.Mtx.RUnlock()
+	if !ok {
+		return nil, ErrMissingInstance
+	}
+
+	detail := &InstanceDetail{InstanceInfo: s.infoFor(id, inst, initTime)}
+	if iw, ok := inst.(*instWrapper); ok {
+		if err := iw.LastError(); err != nil {
+			detail.LastError = err.Error()
+		}
+	}
+	return detail, nil
+}
+
+// HandleListInstances serves GET /v1/instances, optionally filtered by the
+// "initiator", "min_age" and "phase" query parameters.
+func (s *Switch) HandleListInstances(w http.ResponseWriter, r *http.Request) {
+	filter := InstanceFilter{
+		InitiatorFingerprint: r.URL.Query().Get("initiator"),
+		Phase:                r.URL.Query().Get("phase"),
+	}
+	if raw := r.URL.Query().Get("min_age"); raw != "" {
+		secs, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "invalid min_age: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.MinAge = time.Duration(secs) * time.Second
+	}
+
+	writeJSON(w, s.ListInstances(filter))
+}
+
+// HandleInstanceDetail serves GET /v1/instances/detail?instance_id=<hex>.
+func (s *Switch) HandleInstanceDetail(w http.ResponseWriter, r *http.Request) {
+	idHex := r.URL.Query().Get("instance_id")
+	raw, err := hex.DecodeString(idHex)
+	if err != nil || len(raw) != len(InstanceID{}) {
+		http.Error(w, "invalid or missing instance_id", http.StatusBadRequest)
+		return
+	}
+	var id InstanceID
+	copy(id[:], raw)
+
+	detail, err := s.InstanceDetail(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, detail)
+}
+
+// HandleQueueStatus serves GET /v1/admission-queue, reporting how many
+// init requests are waiting for a ceremony slot.
+func (s *Switch) HandleQueueStatus(w http.ResponseWriter, r *http.Request) {
+	if s.Admission == nil {
+		writeJSON(w, AdmissionQueueStatus{})
+		return
+	}
+	writeJSON(w, s.Admission.Status())
+}
+
+// RegisterIntrospectionHandlers wires the query API onto mux, under
+// /v1/instances, /v1/instances/detail and /v1/admission-queue.
+func (s *Switch) RegisterIntrospectionHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/instances", s.HandleListInstances)
+	mux.HandleFunc("/v1/instances/detail", s.HandleInstanceDetail)
+	mux.HandleFunc("/v1/admission-queue", s.HandleQueueStatus)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,108 @@
+package operator
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceFilterMatches(t *testing.T) {
+	info := InstanceInfo{
+		InitiatorFingerprint: "abc123",
+		Elapsed:              "2m0s",
+		Phase:                "deal",
+	}
+
+	require.True(t, InstanceFilter{}.matches(info))
+
+	require.True(t, InstanceFilter{InitiatorFingerprint: "abc123"}.matches(info))
+	require.False(t, InstanceFilter{InitiatorFingerprint: "other"}.matches(info))
+
+	require.True(t, InstanceFilter{Phase: "deal"}.matches(info))
+	require.False(t, InstanceFilter{Phase: "response"}.matches(info))
+
+	require.True(t, InstanceFilter{MinAge: time.Minute}.matches(info))
+	require.False(t, InstanceFilter{MinAge: 5 * time.Minute}.matches(info))
+}
+
+func TestInstanceFilterMatchesUnparseableElapsed(t *testing.T) {
+	info := InstanceInfo{Elapsed: "not-a-duration"}
+	require.False(t, InstanceFilter{MinAge: time.Second}.matches(info))
+}
+
+func TestHandleListInstancesBadMinAge(t *testing.T) {
+	s := newTestSwitch()
+	req := httptest.NewRequest(http.MethodGet, "/v1/instances?min_age=not-a-number", nil)
+	w := httptest.NewRecorder()
+
+	s.HandleListInstances(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleListInstancesEmpty(t *testing.T) {
+	s := newTestSwitch()
+	req := httptest.NewRequest(http.MethodGet, "/v1/instances", nil)
+	w := httptest.NewRecorder()
+
+	s.HandleListInstances(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, "[]", w.Body.String())
+}
+
+func TestHandleInstanceDetailMissingID(t *testing.T) {
+	s := newTestSwitch()
+	req := httptest.NewRequest(http.MethodGet, "/v1/instances/detail", nil)
+	w := httptest.NewRecorder()
+
+	s.HandleInstanceDetail(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleInstanceDetailInvalidHex(t *testing.T) {
+	s := newTestSwitch()
+	req := httptest.NewRequest(http.MethodGet, "/v1/instances/detail?instance_id=not-hex", nil)
+	w := httptest.NewRecorder()
+
+	s.HandleInstanceDetail(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleInstanceDetailWrongLength(t *testing.T) {
+	s := newTestSwitch()
+	req := httptest.NewRequest(http.MethodGet, "/v1/instances/detail?instance_id=abcd", nil)
+	w := httptest.NewRecorder()
+
+	s.HandleInstanceDetail(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleInstanceDetailUnknownInstance(t *testing.T) {
+	s := newTestSwitch()
+	id := InstanceID{1, 2, 3}
+	req := httptest.NewRequest(http.MethodGet, "/v1/instances/detail?instance_id="+hex.EncodeToString(id[:]), nil)
+	w := httptest.NewRecorder()
+
+	s.HandleInstanceDetail(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleQueueStatusNoAdmissionQueue(t *testing.T) {
+	s := newTestSwitch()
+	req := httptest.NewRequest(http.MethodGet, "/v1/admission-queue", nil)
+	w := httptest.NewRecorder()
+
+	s.HandleQueueStatus(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"depth":0,"active":0,"max_concurrent":0,"max_depth":0}`, w.Body.String())
+}
@@ -1,9 +1,7 @@
 package operator
 
 import (
-	"bytes"
 	"crypto/rsa"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -36,39 +34,162 @@ type Instance interface {
 	ReadError() error
 	VerifyInitiatorMessage(msg, sig []byte) error
 	GetLocalOwner() *dkg.LocalOwner
+	Close()
 }
 
 type instWrapper struct {
 	*dkg.LocalOwner
 	respChan chan []byte
 	errChan  chan error
+	errDone  chan struct{} // closed once errDrain has read errChan and cached lastErr
+	stop     chan struct{} // closed by Close to tell a blocked errDrain to give up
+
+	mtx                  sync.RWMutex
+	phase                Phase
+	processedMsgs        int
+	reshare              bool
+	operatorIDs          []OperatorID
+	initiatorFingerprint InitiatorFingerprint
+	lastErr              error
+}
+
+// errDrain is the single reader of errChan: errChan only ever carries one
+// value, so ReadError and LastError must not both receive from it
+// directly, or whichever loses the race blocks forever on a channel
+// nothing will ever send on again. It is started once by CreateInstance
+// and caches the result in lastErr behind errDone, which ReadError blocks
+// on and LastError polls. If the instance is torn down before a ceremony
+// ever errors, stop is closed (by Close, from teardownInstance) so
+// errDrain returns instead of leaking a goroutine parked on errChan
+// forever; callers must not invoke ReadError after Close.
+func (iw *instWrapper) errDrain() {
+	select {
+	case err := <-iw.errChan:
+		iw.mtx.Lock()
+		iw.lastErr = err
+		iw.mtx.Unlock()
+		close(iw.errDone)
+	case <-iw.stop:
+	}
+}
+
+// Close tells a still-running errDrain to give up instead of leaking a
+// goroutine parked on errChan for the life of the process. Called by
+// teardownInstance once the instance is no longer live.
+func (iw *instWrapper) Close() {
+	close(iw.stop)
 }
 
 func (iw *instWrapper) ReadResponse() []byte {
-	return <-iw.respChan
+	resp := <-iw.respChan
+	iw.mtx.Lock()
+	// Only the response read after the response round is the final one.
+	if iw.phase == PhaseResponse {
+		iw.phase = PhaseComplete
+	}
+	iw.mtx.Unlock()
+	return resp
 }
+
+// ReadError blocks until the instance's processing error, if any, is
+// available, matching the pre-existing Instance interface contract (and
+// the still-blocking ReadResponse it is symmetric with).
 func (iw *instWrapper) ReadError() error {
-	return <-iw.errChan
+	<-iw.errDone
+	iw.mtx.RLock()
+	defer iw.mtx.RUnlock()
+	return iw.lastErr
+}
+
+// LastError returns the most recently observed processing error, if any,
+// without blocking, for callers like the introspection API that want a
+// point-in-time snapshot rather than to wait for one.
+func (iw *instWrapper) LastError() error {
+	select {
+	case <-iw.errDone:
+		iw.mtx.RLock()
+		defer iw.mtx.RUnlock()
+		return iw.lastErr
+	default:
+		return nil
+	}
+}
+
+// Process tracks how many DKG messages this instance has seen so the
+// introspection API can report an approximate protocol phase, then
+// delegates to the embedded LocalOwner to do the actual work.
+func (iw *instWrapper) Process(signer uint64, msg *wire.SignedTransport) error {
+	err := iw.LocalOwner.Process(signer, msg)
+	iw.mtx.Lock()
+	iw.processedMsgs++
+	if iw.phase != PhaseComplete {
+		peerCount := len(iw.operatorIDs) - 1
+		iw.phase = phaseForMessageCount(iw.processedMsgs, peerCount)
+	}
+	iw.mtx.Unlock()
+	return err
 }
 
 type InstanceID [24]byte
 
-func (s *Switch) CreateInstance(reqID [24]byte, init *wire.Init, initiatorPublicKey *rsa.PublicKey, secretShare *kyber_dkg.DistKeyShare) (Instance, []byte, error) {
+// loadReshareSecret decodes the reshare secret share stored under oldID by
+// a prior ceremony, as needed by CreateInstance's secretShare argument for
+// a reshare. It returns ok=false, with no error, if no share is stored
+// under oldID.
+func (s *Switch) loadReshareSecret(oldID InstanceID) (*kyber_dkg.DistKeyShare, bool, error) {
+	var shareFromDB basedb.Obj
+	secret := &kyber_dkg.DistKeyShare{}
+	shareFromDB, ok, err := s.DB.Get([]byte("secret"), oldID[:])
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	var privShare dkg.DistKeyShare
+	if err := privShare.Decode(shareFromDB.Value); err != nil {
+		return nil, false, err
+	}
+	var coefs []kyber.Point
+	coefsBytes := utils.SplitBytes(privShare.Commits, 48)
+	for _, c := range coefsBytes {
+		p := bls3.NewBLS12381Suite().G1().Point()
+		if err := p.UnmarshalBinary(c); err != nil {
+			return nil, false, err
+		}
+		coefs = append(coefs, p)
+	}
+	secret.Commits = coefs
+	secretPoint := bls3.NewBLS12381Suite().G1().Scalar()
+	if err := secretPoint.UnmarshalBinary(privShare.Share.V); err != nil {
+		return nil, false, err
+	}
+	secret.Share = &share.PriShare{V: secretPoint, I: privShare.Share.I}
+	return secret, true, nil
+}
+
+func (s *Switch) CreateInstance(reqID InstanceID, init *wire.Init, initiatorPublicKey *rsa.PublicKey, secretShare *kyber_dkg.DistKeyShare) (Instance, []byte, error) {
 
-	verify, err := s.CreateVerifyFunc(append(init.Operators, init.NewOperators...))
+	allOps := append(init.Operators, init.NewOperators...)
+	verify, err := s.CreateVerifyFunc(allOps)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	operatorID := uint64(0)
+	operatorID := OperatorID(0)
 	operatorPubKey := s.PrivateKey.Public().(*rsa.PublicKey)
 	pkBytes, err := crypto.EncodePublicKey(operatorPubKey)
 	if err != nil {
 		return nil, nil, err
 	}
-	for _, op := range append(init.Operators, init.NewOperators...) {
-		if bytes.Equal(op.PubKey, pkBytes) {
-			operatorID = op.ID
+	myPubKey, err := NewOperatorPubKey(pkBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, op := range allOps {
+		opPubKey, err := NewOperatorPubKey(op.PubKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if opPubKey == myPubKey {
+			operatorID = OperatorID(op.ID)
 			break
 		}
 	}
@@ -90,7 +211,7 @@ func (s *Switch) CreateInstance(reqID [24]byte, init *wire.Init, initiatorPublic
 		SignFunc:           s.Sign,
 		VerifyFunc:         verify,
 		Suite:              bls3.NewBLS12381Suite(),
-		ID:                 operatorID,
+		ID:                 uint64(operatorID), // pkgs/dkg.OwnerOpts.ID is owned by the dkg package, not this one; operatorID is validated above and converted back at this boundary
 		OpPrivKey:          s.PrivateKey,
 		Owner:              init.Owner,
 		Nonce:              init.Nonce,
@@ -111,7 +232,28 @@ func (s *Switch) CreateInstance(reqID [24]byte, init *wire.Init, initiatorPublic
 	}
 	s.Logger.Info("Waiting for owner response to init")
 	res := <-bchan
-	return &instWrapper{owner, bchan, owner.ErrorChan}, res, nil
+
+	operatorIDs := make([]OperatorID, 0, len(allOps))
+	for _, op := range allOps {
+		operatorIDs = append(operatorIDs, OperatorID(op.ID))
+	}
+	fingerprint, err := NewInitiatorFingerprint(initiatorPublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	iw := &instWrapper{
+		LocalOwner:           owner,
+		respChan:             bchan,
+		errChan:              owner.ErrorChan,
+		errDone:              make(chan struct{}),
+		stop:                 make(chan struct{}),
+		phase:                PhaseInit,
+		reshare:              len(init.NewOperators) != 0,
+		operatorIDs:          operatorIDs,
+		initiatorFingerprint: fingerprint,
+	}
+	go iw.errDrain()
+	return iw, res, nil
 }
 
 func (s *Switch) Sign(msg []byte) ([]byte, error) {
@@ -120,16 +262,18 @@ func (s *Switch) Sign(msg []byte) ([]byte, error) {
 
 func (s *Switch) CreateVerifyFunc(ops []*wire.Operator) (func(id uint64, msg []byte, sig []byte) error, error) {
 
-	inst_ops := make(map[uint64]*rsa.PublicKey)
+	inst_ops := make(map[OperatorID]*rsa.PublicKey)
 	for _, op := range ops {
 		pk, err := crypto.ParseRSAPubkey(op.PubKey)
 		if err != nil {
 			return nil, err
 		}
-		inst_ops[op.ID] = pk
+		inst_ops[OperatorID(op.ID)] = pk
 	}
+	// id arrives as a raw uint64 off the wire (wire.SignedTransport.Signer);
+	// OperatorID(id) is the validated boundary conversion into this package.
 	return func(id uint64, msg []byte, sig []byte) error {
-		pk, ok := inst_ops[id]
+		pk, ok := inst_ops[OperatorID(id)]
 		if !ok {
 			return errors.New("ops not exist for this instance")
 		}
@@ -145,22 +289,38 @@ type Switch struct {
 
 	PrivateKey *rsa.PrivateKey
 	DB         *kv.BadgerDB
+	Journal    *journal
+	Admission  *AdmissionQueue
+
+	// releases holds the admission-slot release func for every live
+	// instance, keyed by reqID, so the slot is only handed back once the
+	// instance is actually torn down (completed, expired, or replaced),
+	// not as soon as InitInstance's synchronous Init/Exchange round
+	// returns. See teardownInstance.
+	releases map[InstanceID]func()
 
 	//broadcastF func([]byte) error
 }
 
 func NewSwitch(pv *rsa.PrivateKey, logger *zap.Logger, db *kv.BadgerDB) *Switch {
-	return &Switch{
+	s := &Switch{
 		Logger:           logger,
 		Mtx:              sync.RWMutex{},
 		InstanceInitTime: make(map[InstanceID]time.Time, MaxInstances),
 		Instances:        make(map[InstanceID]Instance, MaxInstances),
+		releases:         make(map[InstanceID]func(), MaxInstances),
 		PrivateKey:       pv,
 		DB:               db,
+		Journal:          newJournal(db),
+		Admission:        NewAdmissionQueue(MaxInstances, DefaultAdmissionQueueDepth, DefaultAdmissionWait),
+	}
+	if err := s.Recover(); err != nil {
+		logger.Warn("failed to recover DKG instances from journal", zap.Error(err))
 	}
+	return s
 }
 
-func (s *Switch) InitInstance(reqID [24]byte, initMsg *wire.Transport, initiatorSignature []byte) ([]byte, error) {
+func (s *Switch) InitInstance(reqID InstanceID, initMsg *wire.Transport, initiatorSignature []byte) ([]byte, error) {
 	logger := s.Logger.With(zap.String("reqid", hex.EncodeToString(reqID[:])))
 	logger.Info("initializing DKG instance")
 	init := &wire.Init{}
@@ -181,7 +341,11 @@ func (s *Switch) InitInstance(reqID [24]byte, initMsg *wire.Transport, initiator
 	if err != nil {
 		return nil, fmt.Errorf("init message signature isn't valid: %s", err.Error())
 	}
-	s.Logger.Info(fmt.Sprintf("init message signature is successfully verified, from: %x", sha256.Sum256(initiatorPubKey.N.Bytes())))
+	initiatorFP, err := NewInitiatorFingerprint(initiatorPubKey)
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info(fmt.Sprintf("init message signature is successfully verified, from: %s", initiatorFP))
 	// Check if we run reshare
 	var reshare bool
 	if len(init.NewOperators) != 0 {
@@ -191,40 +355,16 @@ func (s *Switch) InitInstance(reqID [24]byte, initMsg *wire.Transport, initiator
 	if reshare {
 		s.Logger.Info("Starting resharing protocol")
 		// try to get old local owner first
-		var shareFromDB basedb.Obj
-		secret := &kyber_dkg.DistKeyShare{}
-		shareFromDB, ok, err := s.DB.Get([]byte("secret"), init.OldID[:])
+		secret, ok, err := s.loadReshareSecret(init.OldID)
 		if err != nil {
 			return nil, err
 		}
 		if ok {
-			var privShare dkg.DistKeyShare
-			err := privShare.Decode(shareFromDB.Value)
-			if err != nil {
-				return nil, err
-			}
-			var coefs []kyber.Point
-			coefsBytes := utils.SplitBytes(privShare.Commits, 48)
-			for _, c := range coefsBytes {
-				p := bls3.NewBLS12381Suite().G1().Point()
-				err := p.UnmarshalBinary(c)
-				if err != nil {
-					return nil, err
-				}
-				coefs = append(coefs, p)
-			}
-			secret.Commits = coefs
-			secretPoint := bls3.NewBLS12381Suite().G1().Scalar()
-			err = secretPoint.UnmarshalBinary(privShare.Share.V)
-			if err != nil {
-				return nil, err
-			}
-			secret.Share = &share.PriShare{V: secretPoint, I: privShare.Share.I}
 			s.Mtx.Lock()
-			l := len(s.Instances)
-			if l >= MaxInstances {
-				cleaned := s.CleanInstances() // not thread safe
-				if l-cleaned >= MaxInstances {
+			if len(s.Instances) >= MaxInstances {
+				s.CleanInstances()  // stale instances may free up a slot; caller holds s.Mtx
+				defer s.GCJournal() // runs after s.Mtx.Unlock(); GCJournal must not run under s.Mtx
+				if len(s.Instances) >= MaxInstances {
 					s.Mtx.Unlock()
 					return nil, ErrMaxInstances
 				}
@@ -236,32 +376,42 @@ func (s *Switch) InitInstance(reqID [24]byte, initMsg *wire.Transport, initiator
 					s.Mtx.Unlock()
 					return nil, ErrAlreadyExists
 				}
-				delete(s.Instances, reqID)
-				delete(s.InstanceInitTime, reqID)
+				s.teardownInstance(reqID)
 			}
 			s.Mtx.Unlock()
+			release, err := s.AdmitInit(reqID, initiatorPubKey)
+			if err != nil {
+				return nil, err
+			}
 			inst, resp, err := s.CreateInstance(reqID, init, initiatorPubKey, secret)
 			if err != nil {
+				release()
 				return nil, err
 			}
 			s.Mtx.Lock()
 			_, ok = s.Instances[reqID]
 			if ok {
 				s.Mtx.Unlock()
+				inst.Close()
+				release()
 				return nil, ErrAlreadyExists
 			}
 			s.Instances[reqID] = inst
 			s.InstanceInitTime[reqID] = time.Now()
+			s.releases[reqID] = release
 			s.Mtx.Unlock()
+			if err := s.Journal.WriteInit(reqID, init.InitiatorPublicKey, initMsg.Data, s.InstanceInitTime[reqID]); err != nil {
+				logger.Warn("failed to journal instance init", zap.Error(err))
+			}
 			return resp, nil
 		}
 	}
 
 	s.Mtx.Lock()
-	l := len(s.Instances)
-	if l >= MaxInstances {
-		cleaned := s.CleanInstances() // not thread safe
-		if l-cleaned >= MaxInstances {
+	if len(s.Instances) >= MaxInstances {
+		s.CleanInstances()  // stale instances may free up a slot; caller holds s.Mtx
+		defer s.GCJournal() // runs after s.Mtx.Unlock(); GCJournal must not run under s.Mtx
+		if len(s.Instances) >= MaxInstances {
 			s.Mtx.Unlock()
 			return nil, ErrMaxInstances
 		}
@@ -273,42 +423,88 @@ func (s *Switch) InitInstance(reqID [24]byte, initMsg *wire.Transport, initiator
 			s.Mtx.Unlock()
 			return nil, ErrAlreadyExists
 		}
-		delete(s.Instances, reqID)
-		delete(s.InstanceInitTime, reqID)
+		s.teardownInstance(reqID)
 	}
 	s.Mtx.Unlock()
+	release, err := s.AdmitInit(reqID, initiatorPubKey)
+	if err != nil {
+		return nil, err
+	}
 	s.Logger.Info("Starting initial DKG protocol")
 	inst, resp, err := s.CreateInstance(reqID, init, initiatorPubKey, nil)
 
 	if err != nil {
+		release()
 		return nil, err
 	}
 	s.Mtx.Lock()
 	_, ok = s.Instances[reqID]
 	if ok {
 		s.Mtx.Unlock()
+		inst.Close()
+		release()
 		return nil, ErrAlreadyExists
 	}
 	s.Instances[reqID] = inst
 	s.InstanceInitTime[reqID] = time.Now()
+	s.releases[reqID] = release
 	s.Mtx.Unlock()
+	if err := s.Journal.WriteInit(reqID, init.InitiatorPublicKey, initMsg.Data, s.InstanceInitTime[reqID]); err != nil {
+		logger.Warn("failed to journal instance init", zap.Error(err))
+	}
 
 	return resp, nil
 
 }
 
+// teardownInstance removes a no-longer-live instance from Switch state,
+// stops its errDrain goroutine, and, if it was holding one, hands its
+// admission slot back to the AdmissionQueue. Callers must hold s.Mtx.
+func (s *Switch) teardownInstance(id InstanceID) {
+	if inst, ok := s.Instances[id]; ok && inst != nil {
+		inst.Close()
+	}
+	delete(s.Instances, id)
+	delete(s.InstanceInitTime, id)
+	if release, ok := s.releases[id]; ok {
+		delete(s.releases, id)
+		release()
+	}
+}
+
+// CleanInstances drops every instance that has run past MaxInstanceTime,
+// releasing its admission slot so the AdmissionQueue's notion of
+// concurrent live ceremonies matches s.Instances. Callers must hold s.Mtx.
+// It does not GC the journal itself: that walks the whole journal
+// keyspace, which callers shouldn't do while holding s.Mtx - call
+// GCJournal once s.Mtx is released instead.
 func (s *Switch) CleanInstances() int {
 	count := 0
 	for id, instime := range s.InstanceInitTime {
 		if time.Now().After(instime.Add(MaxInstanceTime)) {
-			delete(s.Instances, id)
-			delete(s.InstanceInitTime, id)
+			s.teardownInstance(id)
+			if err := s.Journal.Delete(id); err != nil {
+				s.Logger.Warn("failed to clean up journal entry", zap.String("reqid", hex.EncodeToString(id[:])), zap.Error(err))
+			}
 			count++
 		}
 	}
 	return count
 }
 
+// GCJournal prunes journal entries older than MaxInstanceTime. Unlike
+// CleanInstances it does not touch s.Instances, so callers must not hold
+// s.Mtx while calling it: it walks the whole journal keyspace in
+// BadgerDB, which would otherwise stall every other InitInstance,
+// ProcessMessage and ListInstances call for the duration of the scan.
+func (s *Switch) GCJournal() {
+	if cleaned, err := s.Journal.GC(MaxInstanceTime); err != nil {
+		s.Logger.Warn("failed to GC stale journal entries", zap.Error(err))
+	} else if cleaned > 0 {
+		s.Logger.Debug("GC'd stale journal entries", zap.Int("count", cleaned))
+	}
+}
+
 func (s *Switch) ProcessMessage(dkgMsg []byte) ([]byte, error) {
 	// get instanceID
 	st := &wire.MultipleSignedTransports{}
@@ -344,8 +540,18 @@ func (s *Switch) ProcessMessage(dkgMsg []byte) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		tsBytes, err := ts.MarshalSSZ()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Journal.AppendMessage(id, tsBytes, true); err != nil {
+			s.Logger.Warn("failed to journal inbound message", zap.String("reqid", hex.EncodeToString(id[:])), zap.Error(err))
+		}
 	}
 	resp := inst.ReadResponse()
+	if err := s.Journal.AppendMessage(id, resp, false); err != nil {
+		s.Logger.Warn("failed to journal outbound message", zap.String("reqid", hex.EncodeToString(id[:])), zap.Error(err))
+	}
 
 	return resp, nil
 }
@@ -0,0 +1,120 @@
+package operator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSwitch() *Switch {
+	return &Switch{
+		Instances:        make(map[InstanceID]Instance),
+		InstanceInitTime: make(map[InstanceID]time.Time),
+		releases:         make(map[InstanceID]func()),
+	}
+}
+
+func TestTeardownInstanceReleasesAdmissionSlot(t *testing.T) {
+	s := newTestSwitch()
+	id := InstanceID{1}
+	s.Instances[id] = nil
+	s.InstanceInitTime[id] = time.Now()
+
+	released := false
+	s.releases[id] = func() { released = true }
+
+	s.teardownInstance(id)
+
+	require.True(t, released, "teardownInstance must hand the admission slot back")
+	require.NotContains(t, s.Instances, id)
+	require.NotContains(t, s.InstanceInitTime, id)
+	require.NotContains(t, s.releases, id)
+}
+
+func TestTeardownInstanceWithoutAdmissionSlotIsNoop(t *testing.T) {
+	s := newTestSwitch()
+	id := InstanceID{2}
+	s.Instances[id] = nil
+	s.InstanceInitTime[id] = time.Now()
+
+	// No entry in s.releases: teardownInstance must stay safe even if a
+	// caller inserts into s.Instances without registering a release.
+	require.NotPanics(t, func() { s.teardownInstance(id) })
+	require.NotContains(t, s.Instances, id)
+}
+
+func TestReadErrorAndLastErrorDoNotRaceOnErrChan(t *testing.T) {
+	iw := &instWrapper{
+		errChan: make(chan error, 1),
+		errDone: make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	go iw.errDrain()
+
+	require.Nil(t, iw.LastError(), "nothing sent yet")
+
+	sentinel := errors.New("boom")
+	iw.errChan <- sentinel
+
+	// ReadError must still see sentinel even though LastError may poll
+	// errDone concurrently - neither may receive from errChan directly,
+	// or whichever loses that race blocks forever.
+	readErrDone := make(chan error, 1)
+	go func() { readErrDone <- iw.ReadError() }()
+
+	require.Eventually(t, func() bool {
+		return iw.LastError() != nil
+	}, time.Second, time.Millisecond)
+
+	select {
+	case err := <-readErrDone:
+		require.Equal(t, sentinel, err)
+	case <-time.After(time.Second):
+		t.Fatal("ReadError should have unblocked once errDrain cached the error")
+	}
+	require.Equal(t, sentinel, iw.LastError())
+}
+
+func TestCloseStopsErrDrainWhenInstanceNeverErrors(t *testing.T) {
+	iw := &instWrapper{
+		errChan: make(chan error, 1),
+		errDone: make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	drainReturned := make(chan struct{})
+	go func() {
+		iw.errDrain()
+		close(drainReturned)
+	}()
+
+	iw.Close()
+
+	select {
+	case <-drainReturned:
+	case <-time.After(time.Second):
+		t.Fatal("errDrain should return once Close is called on a ceremony that never errored")
+	}
+	require.Nil(t, iw.LastError(), "errDone must stay open: no error was ever observed")
+}
+
+func TestTeardownInstanceClosesInstance(t *testing.T) {
+	s := newTestSwitch()
+	id := InstanceID{3}
+	iw := &instWrapper{
+		errChan: make(chan error, 1),
+		errDone: make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	s.Instances[id] = iw
+	s.InstanceInitTime[id] = time.Now()
+
+	s.teardownInstance(id)
+
+	select {
+	case <-iw.stop:
+	default:
+		t.Fatal("teardownInstance must Close the instance so its errDrain goroutine can exit")
+	}
+}